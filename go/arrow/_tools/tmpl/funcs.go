@@ -0,0 +1,283 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"plugin"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// protectedFuncs names template functions a -funcs plugin may never
+// override, keeping include/template/block semantics stable across
+// invocations regardless of what a plugin registers.
+var protectedFuncs = map[string]bool{
+	"include":  true,
+	"template": true,
+	"block":    true,
+}
+
+func init() {
+	for name, fn := range stdFuncs {
+		funcs[name] = fn
+	}
+}
+
+// stdFuncs is Arrow's built-in standard library of template helpers,
+// mirroring what the Arrow C++ Jinja generators already provide so
+// generator templates can do type munging with named functions instead of
+// inlining it as raw pipelines.
+var stdFuncs = template.FuncMap{
+	"title":      title,
+	"camel":      camelCase,
+	"snake":      snakeCase,
+	"goType":     goType,
+	"arrowType":  arrowTypeName,
+	"sizeOf":     sizeOf,
+	"isFloating": isFloating,
+	"isInteger":  isInteger,
+	"cType":      cType,
+	"printf":     fmt.Sprintf,
+	"tern":       tern,
+	"dict":       dict,
+	"list":       list,
+	"default":    defaultFunc,
+}
+
+// funcsList collects repeated -funcs plugin paths in the order they were
+// given; later plugins are merged in last and so win on name collisions.
+type funcsList []string
+
+func (l *funcsList) String() string { return strings.Join(*l, ", ") }
+func (l *funcsList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// loadFuncs starts from the built-in funcs and, for each path in order,
+// plugin.Opens it and merges the template.FuncMap its exported
+// `func Funcs() template.FuncMap` returns on top, so later plugins (and a
+// plugin over the built-ins) win on any name collision. protectedFuncs are
+// never overridden.
+func loadFuncs(paths []string) (template.FuncMap, error) {
+	merged := copyFuncMap(funcs)
+	for _, path := range paths {
+		fm, err := loadFuncsPlugin(path)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeFuncMaps(merged, fm, path)
+	}
+	return merged, nil
+}
+
+// loadFuncsPlugin opens the shared object at path and calls its exported
+// `func Funcs() template.FuncMap`.
+func loadFuncsPlugin(path string) (template.FuncMap, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening plugin '%s': %w", path, err)
+	}
+
+	sym, err := p.Lookup("Funcs")
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' does not export Funcs: %w", path, err)
+	}
+
+	register, ok := sym.(func() template.FuncMap)
+	if !ok {
+		return nil, fmt.Errorf("plugin '%s': Funcs has the wrong signature, want func() template.FuncMap", path)
+	}
+
+	return register(), nil
+}
+
+func copyFuncMap(fm template.FuncMap) template.FuncMap {
+	out := make(template.FuncMap, len(fm))
+	for name, fn := range fm {
+		out[name] = fn
+	}
+	return out
+}
+
+// mergeFuncMaps merges override on top of base (mutating and returning
+// base), skipping any name in protectedFuncs. source is the plugin path,
+// used only to make the warning for a refused override actionable. Later
+// calls win: merging override2 after override1 lets override2's entries
+// take precedence over override1's.
+func mergeFuncMaps(base, override template.FuncMap, source string) template.FuncMap {
+	for name, fn := range override {
+		if protectedFuncs[name] {
+			log.Printf("-funcs %s: refusing to override built-in directive %q", source, name)
+			continue
+		}
+		base[name] = fn
+	}
+	return base
+}
+
+var arrowToGoType = map[string]string{
+	"Int8": "int8", "Int16": "int16", "Int32": "int32", "Int64": "int64",
+	"Uint8": "uint8", "Uint16": "uint16", "Uint32": "uint32", "Uint64": "uint64",
+	"Float32": "float32", "Float64": "float64",
+	"Boolean": "bool", "String": "string", "Binary": "[]byte",
+}
+
+var goToArrowType = func() map[string]string {
+	m := make(map[string]string, len(arrowToGoType))
+	for arrow, goT := range arrowToGoType {
+		m[goT] = arrow
+	}
+	return m
+}()
+
+var goToCType = map[string]string{
+	"int8": "int8_t", "int16": "int16_t", "int32": "int32_t", "int64": "int64_t",
+	"uint8": "uint8_t", "uint16": "uint16_t", "uint32": "uint32_t", "uint64": "uint64_t",
+	"float32": "float", "float64": "double", "bool": "bool",
+}
+
+var goTypeSizes = map[string]int{
+	"int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4, "float32": 4,
+	"int64": 8, "uint64": 8, "float64": 8,
+}
+
+// goType maps an Arrow type name (e.g. "Int8") to the Go type generator
+// templates should use for it; names it doesn't recognize pass through
+// lowercased on the assumption they're already a Go type.
+func goType(arrowTypeName string) string {
+	if t, ok := arrowToGoType[arrowTypeName]; ok {
+		return t
+	}
+	return strings.ToLower(arrowTypeName)
+}
+
+// arrowTypeName is goType's inverse, mapping a Go type name to its Arrow
+// type name.
+func arrowTypeName(goTypeName string) string {
+	if t, ok := goToArrowType[goTypeName]; ok {
+		return t
+	}
+	return title(goTypeName)
+}
+
+// sizeOf returns the width in bytes of a fixed-width Go numeric type, or 0
+// for anything else (string, []byte, bool, ...).
+func sizeOf(goTypeName string) int { return goTypeSizes[goTypeName] }
+
+func isFloating(goTypeName string) bool {
+	return goTypeName == "float32" || goTypeName == "float64"
+}
+
+func isInteger(goTypeName string) bool {
+	switch goTypeName {
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		return true
+	default:
+		return false
+	}
+}
+
+// cType maps a Go type name to the C type used for it in Arrow's C data
+// interface and cgo-facing templates.
+func cType(goTypeName string) string {
+	if t, ok := goToCType[goTypeName]; ok {
+		return t
+	}
+	return goTypeName
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+var snakeBoundaryRe = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// snakeCase converts a CamelCase or PascalCase identifier to snake_case.
+func snakeCase(s string) string {
+	return strings.ToLower(snakeBoundaryRe.ReplaceAllString(s, "${1}_${2}"))
+}
+
+// camelCase converts a snake_case, kebab-case, or space separated
+// identifier to lowerCamelCase.
+func camelCase(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	})
+	for i, p := range parts {
+		p = strings.ToLower(p)
+		if i > 0 {
+			p = title(p)
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, "")
+}
+
+// tern is a ternary operator for templates, which have no `?:` of their own.
+func tern(cond bool, then, els interface{}) interface{} {
+	if cond {
+		return then
+	}
+	return els
+}
+
+// dict builds a map[string]interface{} from alternating key/value
+// arguments, letting templates construct ad hoc data for a sub-template
+// without a matching Go struct.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires an even number of arguments, got %d", len(pairs))
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// list collects its arguments into a []interface{}, for templates that
+// need to build a slice inline to range over or pass to a sub-template.
+func list(items ...interface{}) []interface{} { return items }
+
+// defaultFunc returns val unless it is nil or an empty string, in which
+// case it returns def. Other zero values (0, false, empty slices or maps)
+// are returned as they are.
+func defaultFunc(def, val interface{}) interface{} {
+	if val == nil {
+		return def
+	}
+	if s, ok := val.(string); ok && s == "" {
+		return def
+	}
+	return val
+}
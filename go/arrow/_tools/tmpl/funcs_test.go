@@ -0,0 +1,70 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestMergeFuncMapsPrecedence(t *testing.T) {
+	base := copyFuncMap(template.FuncMap{
+		"greet": func() string { return "base" },
+	})
+
+	override1 := template.FuncMap{
+		"greet": func() string { return "override1" },
+		"extra": func() string { return "override1-extra" },
+	}
+	override2 := template.FuncMap{
+		"greet": func() string { return "override2" },
+	}
+
+	merged := mergeFuncMaps(base, override1, "plugin1.so")
+	merged = mergeFuncMaps(merged, override2, "plugin2.so")
+
+	if got := merged["greet"].(func() string)(); got != "override2" {
+		t.Errorf("greet = %q, want the last-merged plugin's definition", got)
+	}
+	if got := merged["extra"].(func() string)(); got != "override1-extra" {
+		t.Errorf("extra = %q, want override1's definition to survive", got)
+	}
+}
+
+func TestMergeFuncMapsProtectsBuiltins(t *testing.T) {
+	base := copyFuncMap(template.FuncMap{
+		"include": func() string { return "builtin" },
+	})
+
+	merged := mergeFuncMaps(base, template.FuncMap{
+		"include": func() string { return "plugin" },
+	}, "plugin.so")
+
+	if got := merged["include"].(func() string)(); got != "builtin" {
+		t.Errorf("include = %q, want protectedFuncs to keep the built-in directive", got)
+	}
+}
+
+func TestCopyFuncMapIsIndependent(t *testing.T) {
+	orig := template.FuncMap{"f": func() string { return "orig" }}
+	cp := copyFuncMap(orig)
+	cp["f"] = func() string { return "copy" }
+
+	if got := orig["f"].(func() string)(); got != "orig" {
+		t.Errorf("mutating the copy changed the original: got %q", got)
+	}
+}
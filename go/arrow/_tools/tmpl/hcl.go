@@ -0,0 +1,115 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// decodeHCL parses raw as native-syntax HCL and walks it into the same
+// interface{} shape json.Unmarshal/yaml.Unmarshal produce, since
+// hclsimple.Decode/gohcl only decode into a struct with `hcl:"..."` tags
+// and have no generic "decode into a map" mode. Attributes become map
+// entries; a block becomes a nested map under its block type, or a slice
+// of maps if the type repeats (e.g. multiple `type "Int8" { ... }` blocks).
+func decodeHCL(path string, raw []byte) (interface{}, error) {
+	f, diags := hclsyntax.ParseConfig(raw, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported HCL body", path)
+	}
+
+	return hclBodyToMap(body)
+}
+
+func hclBodyToMap(body *hclsyntax.Body) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(body.Attributes)+len(body.Blocks))
+
+	for name, attr := range body.Attributes {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		out[name] = ctyToInterface(v)
+	}
+
+	for _, block := range body.Blocks {
+		child, err := hclBodyToMap(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		for i, label := range block.Labels {
+			child[fmt.Sprintf("_label%d", i)] = label
+		}
+
+		switch existing := out[block.Type].(type) {
+		case nil:
+			out[block.Type] = child
+		case []interface{}:
+			out[block.Type] = append(existing, child)
+		default:
+			out[block.Type] = []interface{}{existing, child}
+		}
+	}
+
+	return out, nil
+}
+
+// ctyToInterface converts a cty.Value decoded from HCL into the same
+// string/float64/bool/nil/map/slice shape encoding/json decodes into (and
+// normalizeYAML turns yaml.v3 output into), so HCL data merges and
+// templates identically to JSON/YAML data.
+func ctyToInterface(v cty.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Bool:
+		return v.True()
+	case t == cty.Number:
+		f, _ := v.AsBigFloat().Float64()
+		return f
+	case t.IsTupleType() || t.IsListType() || t.IsSetType():
+		out := make([]interface{}, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			out = append(out, ctyToInterface(ev))
+		}
+		return out
+	case t.IsObjectType() || t.IsMapType():
+		out := make(map[string]interface{})
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			out[k.AsString()] = ctyToInterface(ev)
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,104 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeHCL(t *testing.T) {
+	raw := []byte(`
+name    = "Int8"
+width   = 8
+signed  = true
+
+type "Int8" {
+  go = "int8"
+}
+
+type "Uint8" {
+  go = "uint8"
+}
+`)
+
+	got, err := decodeHCL("test.hcl", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"name":   "Int8",
+		"width":  8.0,
+		"signed": true,
+		"type": []interface{}{
+			map[string]interface{}{"go": "int8", "_label0": "Int8"},
+			map[string]interface{}{"go": "uint8", "_label0": "Uint8"},
+		},
+	}
+
+	gotMap, ok := got.(map[string]interface{})
+	if !ok {
+		t.Fatalf("decodeHCL returned %T, want map[string]interface{}", got)
+	}
+
+	// "type" blocks accumulate in file order; compare them as a set so this
+	// test checks their contents independently of that order.
+	gotTypes, _ := gotMap["type"].([]interface{})
+	wantTypes, _ := want["type"].([]interface{})
+	if len(gotTypes) != len(wantTypes) {
+		t.Fatalf("type blocks = %#v, want %#v", gotTypes, wantTypes)
+	}
+	for _, wt := range wantTypes {
+		found := false
+		for _, gt := range gotTypes {
+			if reflect.DeepEqual(gt, wt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing type block %#v in %#v", wt, gotTypes)
+		}
+	}
+
+	delete(gotMap, "type")
+	delete(want, "type")
+	if !reflect.DeepEqual(gotMap, want) {
+		t.Errorf("decodeHCL attributes = %#v, want %#v", gotMap, want)
+	}
+}
+
+func TestDecodeHCLInvalid(t *testing.T) {
+	if _, err := decodeHCL("bad.hcl", []byte(`name = `)); err == nil {
+		t.Fatal("expected an error decoding malformed HCL")
+	}
+}
+
+func TestCtyToInterfaceList(t *testing.T) {
+	raw := []byte(`items = ["a", "b", "c"]`)
+	got, err := decodeHCL("list.hcl", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"items": []interface{}{"a", "b", "c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeHCL = %#v, want %#v", got, want)
+	}
+}
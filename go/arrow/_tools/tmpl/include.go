@@ -0,0 +1,135 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// includeList collects repeated -include globs in the order they were given.
+type includeList []string
+
+func (l *includeList) String() string { return strings.Join(*l, ", ") }
+func (l *includeList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func init() {
+	funcs["include"] = includeDirective
+	funcs["code"] = codeDirective
+	funcs["play"] = newPlayDirective(".")
+}
+
+// buildBaseTemplate parses every file matched by includeGlobs (whatever its
+// extension) into a single root template, each under its basename, so a spec's own
+// template can reference them with {{template "partial.tmpl" .}} and
+// {{block}}. Every spec is subsequently executed from a clone of this base,
+// so partials are parsed once per run instead of once per spec.
+func buildBaseTemplate(includeGlobs []string, fm template.FuncMap) (*template.Template, error) {
+	base := template.New("gen").Funcs(fm)
+	for _, glob := range includeGlobs {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("bad -include glob '%s': %w", glob, err)
+		}
+		for _, m := range matches {
+			name := filepath.Base(m)
+			if _, err := base.New(name).Parse(string(mustReadAll(m))); err != nil {
+				return nil, fmt.Errorf("error parsing include '%s': %w", m, err)
+			}
+		}
+	}
+	return base, nil
+}
+
+// includeDirective implements {{include "path"}}: it splices another file's
+// raw contents verbatim into the output, unlike {{template}} which requires
+// the partial to have been pre-registered via -include.
+func includeDirective(path string) (string, error) {
+	return string(mustReadAll(path)), nil
+}
+
+// codeDirective implements {{code "file.go" "start" "end"}}, where start and
+// end are Go regular expressions given without delimiters: it finds
+// the first line matching start and the first subsequent line matching end
+// in file.go, gofmt-normalizes the lines between them, and splices the
+// result into the output. This lets shared logic live once in a real,
+// compiled .go file instead of being copy-pasted across templates.
+func codeDirective(path, startPat, endPat string) (string, error) {
+	startRe, err := regexp.Compile(startPat)
+	if err != nil {
+		return "", fmt.Errorf("bad start pattern %q: %w", startPat, err)
+	}
+	endRe, err := regexp.Compile(endPat)
+	if err != nil {
+		return "", fmt.Errorf("bad end pattern %q: %w", endPat, err)
+	}
+
+	lines := strings.Split(string(mustReadAll(path)), "\n")
+
+	start, end := -1, -1
+	for i, line := range lines {
+		if start == -1 {
+			if startRe.MatchString(line) {
+				start = i
+			}
+			continue
+		}
+		if endRe.MatchString(line) {
+			end = i
+			break
+		}
+	}
+	if start == -1 || end == -1 {
+		return "", fmt.Errorf("%s: no region matching /%s/ ... /%s/", path, startPat, endPat)
+	}
+
+	snippet := strings.Join(lines[start+1:end], "\n")
+	formatted, err := format.Source([]byte(snippet))
+	if err != nil {
+		// the region isn't standalone-valid Go (e.g. a dangling block),
+		// so splice it in as-is rather than failing the whole generator.
+		return snippet, nil
+	}
+	return string(formatted), nil
+}
+
+// newPlayDirective returns the {{play "file.go"}} implementation used while
+// generating the output in outDir. Go only recognizes a build constraint as
+// the first thing in a file, so it can't be spliced inline mid-template;
+// and the played file keeps its own `package` clause, so concatenating it
+// into the spec's output would produce two `package` clauses. Instead the
+// played file is copied, build-tagged to be excluded from normal
+// compilation, to its own sibling file next to the spec's output, and the
+// template gets back only a comment pointing at it.
+func newPlayDirective(outDir string) func(path string) (string, error) {
+	return func(path string) (string, error) {
+		sidecar := filepath.Join(outDir, filepath.Base(path))
+		content := append([]byte("//go:build ignore\n// +build ignore\n\n"), mustReadAll(path)...)
+		if err := os.WriteFile(sidecar, content, 0o644); err != nil {
+			return "", fmt.Errorf("error writing play sidecar '%s': %w", sidecar, err)
+		}
+		return fmt.Sprintf("// play: see %s (excluded from the build via `//go:build ignore`)", sidecar), nil
+	}
+}
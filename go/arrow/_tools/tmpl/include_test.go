@@ -0,0 +1,101 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncludeDirective(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "partial.txt")
+	if err := os.WriteFile(path, []byte("raw content\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := includeDirective(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "raw content\n" {
+		t.Errorf("includeDirective = %q, want %q", got, "raw content\n")
+	}
+}
+
+func TestCodeDirective(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "source.go")
+	src := "package foo\n\n// start\nfunc Bar() int {\n\treturn 1\n}\n\n// end\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := codeDirective(path, "// start", "// end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "func Bar() int") {
+		t.Errorf("codeDirective = %q, want it to contain the region between the markers", got)
+	}
+}
+
+func TestCodeDirectiveNoMatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "source.go")
+	if err := os.WriteFile(path, []byte("package foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := codeDirective(path, "// start", "// end"); err == nil {
+		t.Fatal("expected an error when no region matches the markers")
+	}
+}
+
+func TestNewPlayDirective(t *testing.T) {
+	srcDir := t.TempDir()
+	outDir := t.TempDir()
+
+	playedPath := filepath.Join(srcDir, "example.go")
+	if err := os.WriteFile(playedPath, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	play := newPlayDirective(outDir)
+	comment, err := play(playedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sidecar := filepath.Join(outDir, "example.go")
+	if !strings.Contains(comment, sidecar) {
+		t.Errorf("comment = %q, want it to reference %q", comment, sidecar)
+	}
+
+	content, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatalf("sidecar file was not written: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "//go:build ignore\n") {
+		t.Errorf("sidecar content = %q, want it to start with the build constraint", content)
+	}
+	if !strings.Contains(string(content), "func main() {}") {
+		t.Errorf("sidecar content = %q, want it to contain the played file's body", content)
+	}
+}
@@ -18,17 +18,27 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"go/format"
+	"io"
 	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/apache/arrow/go/v17/internal/json"
+	"gopkg.in/yaml.v3"
 )
 
 const Ext = ".tmpl"
@@ -84,27 +94,47 @@ func (l listValue) Set(v string) error {
 
 func main() {
 	var (
-		dataArg = flag.String("data", "", "input JSON data")
-		gi      = flag.Bool("i", false, "run goimports")
-		in      = &data{D: make(listValue)}
+		dataArgs     dataFiles
+		includeGlobs includeList
+		funcsPaths   funcsList
+		dataFormat   = flag.String("data-format", "auto", "format of -data files: auto, json, yaml, or hcl")
+		gi           = flag.Bool("i", false, "run goimports")
+		jobs         = flag.Int("j", 1, "number of specs to process concurrently")
+		cache        = flag.Bool("cache", false, "skip regenerating a spec whose template, data, -include partials, -funcs plugins, formatter, and toolchain are unchanged since the last run, via a .stamp file next to each generated output")
+		verbose      = flag.Bool("v", false, "report per-file timings")
+		schemaPath   = flag.String("schema", "", "JSON Schema (draft 2020-12) file to validate -data against before generating")
+		schemaGen    = flag.Bool("schema-gen", false, "walk -data and emit a starter JSON Schema to -schema (or stdout) instead of generating")
+		in           = &data{D: make(listValue)}
 	)
 
+	flag.Var(&dataArgs, "data", "input data file (JSON, YAML, or HCL); may be repeated, later files are deep-merged over earlier ones")
 	flag.Var(&in.D, "d", "-d NAME=VALUE")
+	flag.Var(&includeGlobs, "include", "glob of partial templates to make available via {{template}}/{{block}}; may be repeated")
+	flag.Var(&funcsPaths, "funcs", "shared object exposing func Funcs() template.FuncMap to merge on top of the built-ins; may be repeated, later wins")
 
 	flag.Parse()
-	if *dataArg == "" {
+	if len(dataArgs) == 0 {
 		errExit("data option is required")
 	}
 
+	formatterName := "format.Source"
 	if *gi {
 		if _, err := exec.LookPath("goimports"); err != nil {
 			errExit("failed to find goimports: %s", err.Error())
 		}
 		formatter = formatSource
+		formatterName = "goimports"
 	} else {
 		formatter = format.Source
 	}
 
+	in.In = readData(dataArgs, *dataFormat)
+
+	if *schemaGen {
+		writeSchemaSkeleton(in.In, *schemaPath)
+		return
+	}
+
 	paths := flag.Args()
 	if len(paths) == 0 {
 		errExit("no tmpl files specified")
@@ -116,8 +146,62 @@ func main() {
 		specs[i] = pathSpec{in: in, out: out}
 	}
 
-	in.In = readData(*dataArg)
-	process(in, specs)
+	mergedFuncs, err := loadFuncs(funcsPaths)
+	if err != nil {
+		errExit("error loading -funcs plugins: %s", err.Error())
+	}
+
+	base, err := buildBaseTemplate(includeGlobs, mergedFuncs)
+	if err != nil {
+		errExit("error loading -include templates: %s", err.Error())
+	}
+
+	envHash, err := hashEnv(includeGlobs, funcsPaths)
+	if err != nil {
+		errExit("error hashing -include/-funcs inputs: %s", err.Error())
+	}
+
+	var schemaVersion string
+	if *schemaPath != "" {
+		schema, version, err := loadSchema(*schemaPath)
+		if err != nil {
+			errExit("error loading schema '%s': %s", *schemaPath, err.Error())
+		}
+		if err := validateAgainstSchema(schema, in.In); err != nil {
+			errExit("data failed schema validation:\n%s", err.Error())
+		}
+		schemaVersion = version
+	}
+
+	process(in, specs, runOpts{
+		jobs:          *jobs,
+		cache:         *cache,
+		verbose:       *verbose,
+		formatterName: formatterName,
+		base:          base,
+		envHash:       envHash,
+		schemaVersion: schemaVersion,
+	})
+}
+
+// runOpts controls how process fans work out across specs and whether
+// previously generated outputs may be skipped via the on-disk cache.
+type runOpts struct {
+	jobs          int
+	cache         bool
+	verbose       bool
+	formatterName string
+	base          *template.Template
+	// envHash folds in everything that can change a spec's output besides
+	// its own template and -data: the -include partials and -funcs plugins
+	// that were loaded for this run. It's computed once per invocation,
+	// like dataHash, since it doesn't vary per spec.
+	envHash string
+	// schemaVersion is the $id version parsed from -schema, if any. Once
+	// set, every spec must declare a matching {{schemaVersion "..."}} or
+	// generation fails closed rather than silently drifting from the
+	// locked schema.
+	schemaVersion string
 }
 
 func mustReadAll(path string) []byte {
@@ -129,13 +213,136 @@ func mustReadAll(path string) []byte {
 	return data
 }
 
-func readData(path string) interface{} {
-	data := mustReadAll(path)
+// dataFiles collects repeated -data flags in the order they were given.
+type dataFiles []string
+
+func (d *dataFiles) String() string { return strings.Join(*d, ", ") }
+func (d *dataFiles) Set(v string) error {
+	*d = append(*d, v)
+	return nil
+}
+
+// readData loads and deep-merges one or more -data files. Each file is
+// decoded according to -data-format (or, when that is "auto", its
+// extension: .yaml/.yml, .hcl, otherwise JSON) into the same interface{}
+// shape the templates already consume, and later files win over earlier
+// ones: scalars are overwritten, slices are concatenated, and maps are
+// merged recursively.
+func readData(paths []string, format string) interface{} {
+	var merged interface{}
+	for _, path := range paths {
+		v := readDataFile(path, format)
+		merged = mergeData(merged, v)
+	}
+	return merged
+}
+
+func readDataFile(path, format string) interface{} {
+	raw := mustReadAll(path)
+
+	resolved := format
+	if resolved == "auto" {
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			resolved = "yaml"
+		case ".hcl":
+			resolved = "hcl"
+		default:
+			resolved = "json"
+		}
+	}
+
 	var v interface{}
-	if err := json.Unmarshal(StripComments(data), &v); err != nil {
-		errExit("invalid JSON data: %s", err.Error())
+	switch resolved {
+	case "yaml":
+		if err := yaml.Unmarshal(raw, &v); err != nil {
+			errExit("invalid YAML data in '%s': %s", path, err.Error())
+		}
+		return normalizeYAML(v)
+	case "hcl":
+		v, err := decodeHCL(path, raw)
+		if err != nil {
+			errExit("invalid HCL data in '%s': %s", path, err.Error())
+		}
+		return v
+	default:
+		if err := json.Unmarshal(StripComments(raw), &v); err != nil {
+			errExit("invalid JSON data in '%s': %s", path, err.Error())
+		}
+		return v
 	}
-	return v
+}
+
+// normalizeYAML converts the map[string]interface{} keys that yaml.v3
+// produces for mapping nodes, and the int/int64/uint64 it produces for
+// integer scalars, into the shapes encoding/json's decoder already
+// produces (map[string]interface{} and float64), so YAML data merges and
+// templates the same way as JSON data, and validates against a JSON
+// Schema the same way too.
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[k] = normalizeYAML(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = normalizeYAML(val)
+		}
+		return out
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	default:
+		return t
+	}
+}
+
+// mergeData deep-merges b over a: maps merge recursively key by key,
+// slices concatenate (a's elements followed by b's), a nil b leaves a as it
+// is, and anything else (including a type mismatch) simply takes b, the
+// later value.
+func mergeData(a, b interface{}) interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok && bok {
+		out := make(map[string]interface{}, len(am)+len(bm))
+		for k, v := range am {
+			out[k] = v
+		}
+		for k, v := range bm {
+			if existing, ok := out[k]; ok {
+				out[k] = mergeData(existing, v)
+			} else {
+				out[k] = v
+			}
+		}
+		return out
+	}
+
+	as, asok := a.([]interface{})
+	bs, bsok := b.([]interface{})
+	if asok && bsok {
+		out := make([]interface{}, 0, len(as)+len(bs))
+		out = append(out, as...)
+		out = append(out, bs...)
+		return out
+	}
+
+	return b
 }
 
 func fileMode(path string) os.FileMode {
@@ -149,40 +356,201 @@ func fileMode(path string) os.FileMode {
 var funcs = template.FuncMap{
 	"lower": strings.ToLower,
 	"upper": strings.ToUpper,
+	// schemaVersion is overridden per spec execution to record the declared
+	// version, which is checked only when -schema is set; left as a no-op
+	// here so templates may call it unconditionally.
+	"schemaVersion": func(string) string { return "" },
 }
 
-func process(data interface{}, specs []pathSpec) {
+func process(data interface{}, specs []pathSpec, opts runOpts) {
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		queue    = make(chan pathSpec)
+		errs     = make(chan error, len(specs))
+		dataHash = hashData(data)
+	)
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for spec := range queue {
+				start := time.Now()
+				skipped, err := processOne(data, spec, opts, dataHash)
+				if err != nil {
+					errs <- fmt.Errorf("error processing template '%s': %w", spec.in, err)
+					continue
+				}
+				if opts.verbose {
+					status := "generated"
+					if skipped {
+						status = "cached"
+					}
+					log.Printf("%s %s in %s", status, spec, time.Since(start))
+				}
+			}
+		}()
+	}
+
 	for _, spec := range specs {
-		var (
-			t   *template.Template
-			err error
-		)
-		t, err = template.New("gen").Funcs(funcs).Parse(string(mustReadAll(spec.in)))
-		if err != nil {
-			errExit("error processing template '%s': %s", spec.in, err.Error())
+		queue <- spec
+	}
+	close(queue)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		errExit("%s", err.Error())
+	}
+}
+
+// processOne generates a single spec's output, consulting the on-disk cache
+// (when opts.cache is set) to skip writing outputs that are unchanged. It
+// returns true when the cached output was reused instead of regenerated.
+func processOne(data interface{}, spec pathSpec, opts runOpts, dataHash string) (bool, error) {
+	tmplBytes := mustReadAll(spec.in)
+
+	// The stamp lives next to the generated file itself, not in a shared
+	// directory keyed by basename, so two specs that happen to produce
+	// same-named outputs in different directories can't clobber each
+	// other's cache entry.
+	stampPath := spec.out + ".stamp"
+	var key string
+	if opts.cache && dataHash != "" {
+		key = cacheKey(tmplBytes, dataHash, opts.formatterName, opts.envHash)
+		if existing, err := ioutil.ReadFile(stampPath); err == nil && string(existing) == key {
+			// A stamp match only means the inputs haven't changed; the
+			// output itself may have been deleted or cleaned separately,
+			// in which case it still needs to be (re)written.
+			if _, err := os.Stat(spec.out); err == nil {
+				return true, nil
+			}
 		}
+	}
+
+	t, err := opts.base.Clone()
+	if err != nil {
+		return false, fmt.Errorf("error cloning base template: %w", err)
+	}
+
+	var declaredSchemaVersion string
+	t = t.Funcs(template.FuncMap{
+		"schemaVersion": func(v string) string {
+			declaredSchemaVersion = v
+			return ""
+		},
+		"play": newPlayDirective(filepath.Dir(spec.out)),
+	})
+
+	t, err = t.Parse(string(tmplBytes))
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	if spec.IsGoFile() {
+		// preamble
+		fmt.Fprintf(&buf, "// Code generated by %s. DO NOT EDIT.\n", spec.in)
+		fmt.Fprintln(&buf)
+	}
+	if err := t.Execute(&buf, data); err != nil {
+		return false, err
+	}
 
-		var buf bytes.Buffer
-		if spec.IsGoFile() {
-			// preamble
-			fmt.Fprintf(&buf, "// Code generated by %s. DO NOT EDIT.\n", spec.in)
-			fmt.Fprintln(&buf)
+	if opts.schemaVersion != "" && declaredSchemaVersion != opts.schemaVersion {
+		if declaredSchemaVersion == "" {
+			return false, fmt.Errorf("template does not declare {{schemaVersion \"...\"}}, but -schema pins %q; every template must pin itself once -schema is set", opts.schemaVersion)
 		}
-		err = t.Execute(&buf, data)
+		return false, fmt.Errorf("template declares schemaVersion %q but -schema is %q", declaredSchemaVersion, opts.schemaVersion)
+	}
+
+	generated := buf.Bytes()
+	if spec.IsGoFile() {
+		generated, err = formatter(generated)
 		if err != nil {
-			errExit("error executing template '%s': %s", spec.in, err.Error())
+			return false, fmt.Errorf("error formatting: %w", err)
 		}
+	}
 
-		generated := buf.Bytes()
-		if spec.IsGoFile() {
-			generated, err = formatter(generated)
-			if err != nil {
-				errExit("error formatting '%s': %s", spec.in, err.Error())
-			}
+	if err := os.WriteFile(spec.out, generated, fileMode(spec.in)); err != nil {
+		return false, err
+	}
+
+	if opts.cache && dataHash != "" {
+		if err := os.WriteFile(stampPath, []byte(key), 0o644); err != nil {
+			return false, fmt.Errorf("error writing cache stamp: %w", err)
 		}
+	}
+
+	return false, nil
+}
+
+// hashData renders data to JSON so it can be folded into a spec's cache key
+// alongside the template bytes; it is computed once per invocation since all
+// specs in a run share the same -data/-d inputs.
+func hashData(data interface{}) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		// data was already decoded from -data files (or is a listValue), so
+		// this should rarely fail; fall back to "", which processOne treats
+		// as uncacheable, so caching just degrades to "always regenerate"
+		// instead of crashing the run.
+		return ""
+	}
+	return string(b)
+}
+
+// cacheKey hashes everything that can change a spec's generated output:
+// the template source, the data it was rendered with, the -include
+// partials and -funcs plugins loaded alongside it (envHash), the formatter
+// in use, and the toolchain's module version, so a `go` upgrade invalidates
+// stamps.
+func cacheKey(tmplBytes []byte, dataJSON, formatterName, envHash string) string {
+	h := sha256.New()
+	h.Write(tmplBytes)
+	io.WriteString(h, dataJSON)
+	io.WriteString(h, formatterName)
+	io.WriteString(h, moduleVersion())
+	io.WriteString(h, envHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashEnv hashes every -include partial's contents (in sorted, so
+// deterministic, order) and every -funcs plugin file's bytes (in the given
+// order, since that order also determines precedence), so cacheKey changes
+// when either changes even though neither is a spec's own template or data.
+func hashEnv(includeGlobs, funcsPaths []string) (string, error) {
+	var includeFiles []string
+	for _, glob := range includeGlobs {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return "", fmt.Errorf("bad -include glob '%s': %w", glob, err)
+		}
+		includeFiles = append(includeFiles, matches...)
+	}
+	sort.Strings(includeFiles)
+
+	h := sha256.New()
+	for _, f := range includeFiles {
+		h.Write(mustReadAll(f))
+	}
+	for _, p := range funcsPaths {
+		h.Write(mustReadAll(p))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
 
-		os.WriteFile(spec.out, generated, fileMode(spec.in))
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return runtime.Version()
 	}
+	return runtime.Version() + "/" + info.Main.Version
 }
 
 var (
@@ -0,0 +1,197 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestMergeData(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b interface{}
+		want interface{}
+	}{
+		{
+			name: "nil a returns b",
+			a:    nil,
+			b:    map[string]interface{}{"x": 1.0},
+			want: map[string]interface{}{"x": 1.0},
+		},
+		{
+			name: "nil b returns a",
+			a:    map[string]interface{}{"x": 1.0},
+			b:    nil,
+			want: map[string]interface{}{"x": 1.0},
+		},
+		{
+			name: "scalar: b wins",
+			a:    map[string]interface{}{"x": 1.0},
+			b:    map[string]interface{}{"x": 2.0},
+			want: map[string]interface{}{"x": 2.0},
+		},
+		{
+			name: "slices concatenate a then b",
+			a:    map[string]interface{}{"xs": []interface{}{1.0}},
+			b:    map[string]interface{}{"xs": []interface{}{2.0}},
+			want: map[string]interface{}{"xs": []interface{}{1.0, 2.0}},
+		},
+		{
+			name: "maps merge recursively",
+			a:    map[string]interface{}{"m": map[string]interface{}{"a": 1.0}},
+			b:    map[string]interface{}{"m": map[string]interface{}{"b": 2.0}},
+			want: map[string]interface{}{"m": map[string]interface{}{"a": 1.0, "b": 2.0}},
+		},
+		{
+			name: "type mismatch: b wins",
+			a:    map[string]interface{}{"x": map[string]interface{}{"a": 1.0}},
+			b:    map[string]interface{}{"x": "scalar now"},
+			want: map[string]interface{}{"x": "scalar now"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mergeData(tc.a, tc.b)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mergeData(%#v, %#v) = %#v, want %#v", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcessOneCaching(t *testing.T) {
+	formatter = format.Source
+
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "foo.go.tmpl")
+	outPath := filepath.Join(dir, "foo.go")
+	if err := os.WriteFile(tmplPath, []byte("package foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := runOpts{
+		base:          template.New("gen").Funcs(funcs),
+		cache:         true,
+		formatterName: "format.Source",
+	}
+	spec := pathSpec{in: tmplPath, out: outPath}
+
+	skipped, err := processOne(nil, spec, opts, "datahash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped {
+		t.Fatal("first run should generate, not report cached")
+	}
+
+	skipped, err = processOne(nil, spec, opts, "datahash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !skipped {
+		t.Fatal("second run with unchanged inputs should be reported as cached")
+	}
+
+	if err := os.Remove(outPath); err != nil {
+		t.Fatal(err)
+	}
+	skipped, err = processOne(nil, spec, opts, "datahash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped {
+		t.Fatal("a matching stamp must not be trusted once the generated output is gone")
+	}
+	if _, err := os.Stat(outPath); err != nil {
+		t.Fatalf("output should have been regenerated: %v", err)
+	}
+
+	opts.envHash = "partials-or-plugin-changed"
+	skipped, err = processOne(nil, spec, opts, "datahash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped {
+		t.Fatal("an -include/-funcs change (envHash) must invalidate the cache even though the spec's own template didn't change")
+	}
+}
+
+func TestProcessOneCodeAndPlayDirectives(t *testing.T) {
+	formatter = format.Source
+
+	dir := t.TempDir()
+
+	snippetPath := filepath.Join(dir, "snippet.go")
+	snippet := "package snippet\n\n// start\nfunc Shared() int {\n\treturn 1\n}\n\n// end\n"
+	if err := os.WriteFile(snippetPath, []byte(snippet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playedPath := filepath.Join(dir, "example.go")
+	if err := os.WriteFile(playedPath, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmplPath := filepath.Join(dir, "foo.go.tmpl")
+	tmplSrc := `package foo
+
+{{code "` + snippetPath + `" "// start" "// end"}}
+
+{{play "` + playedPath + `"}}
+`
+	if err := os.WriteFile(tmplPath, []byte(tmplSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outPath := filepath.Join(dir, "foo.go")
+	opts := runOpts{
+		base:          template.New("gen").Funcs(funcs),
+		formatterName: "format.Source",
+	}
+	spec := pathSpec{in: tmplPath, out: outPath}
+
+	if _, err := processOne(nil, spec, opts, "datahash"); err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(generated), "func Shared() int") {
+		t.Errorf("generated output = %q, want it to contain the {{code}}-spliced snippet", generated)
+	}
+
+	sidecar := filepath.Join(dir, "example.go")
+	if !strings.Contains(string(generated), sidecar) {
+		t.Errorf("generated output = %q, want a comment pointing at the {{play}} sidecar %q", generated, sidecar)
+	}
+	sidecarContent, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatalf("{{play}} sidecar was not written: %v", err)
+	}
+	if !strings.HasPrefix(string(sidecarContent), "//go:build ignore\n") {
+		t.Errorf("sidecar content = %q, want it to start with the build constraint", sidecarContent)
+	}
+}
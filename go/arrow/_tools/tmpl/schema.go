@@ -0,0 +1,152 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var schemaIDVersionRe = regexp.MustCompile(`v\d+(\.\d+)*(?:\.json)?$`)
+
+// loadSchema compiles the draft 2020-12 schema at path and returns it
+// alongside the version suffix of its $id (e.g. "v1"), which specs can
+// pin themselves to via {{schemaVersion "v1"}}.
+func loadSchema(path string) (*jsonschema.Schema, string, error) {
+	schema, err := jsonschema.Compile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version := ""
+	// Location is the schema's resolved $id with a trailing "#" fragment
+	// identifier (e.g. ".../tmpl-data-v1.json#"), so strip that before
+	// matching the version suffix.
+	location := strings.TrimSuffix(schema.Location, "#")
+	if m := schemaIDVersionRe.FindString(location); m != "" {
+		version = strings.TrimSuffix(m, ".json")
+	}
+
+	return schema, version, nil
+}
+
+// validateAgainstSchema validates in against schema, rendering any failures
+// as one line per leaf error with its JSON-pointer instance location and
+// the validator's message; a missing required field is reported at the
+// object that lacks it, e.g. "/Types/3: missing properties: 'CType'".
+func validateAgainstSchema(schema *jsonschema.Schema, in interface{}) error {
+	err := schema.Validate(in)
+	if err == nil {
+		return nil
+	}
+
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	var lines []string
+	for _, cause := range leafCauses(ve) {
+		lines = append(lines, fmt.Sprintf("%s: %s", cause.InstanceLocation, cause.Message))
+	}
+	return fmt.Errorf("%s", strings.Join(lines, "\n"))
+}
+
+// leafCauses flattens a jsonschema.ValidationError tree down to its
+// leaves, which are where the actionable messages (required, type
+// mismatch, etc.) live; the intermediate nodes just say "doesn't validate".
+func leafCauses(ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return []*jsonschema.ValidationError{ve}
+	}
+	var out []*jsonschema.ValidationError
+	for _, c := range ve.Causes {
+		out = append(out, leafCauses(c)...)
+	}
+	return out
+}
+
+// writeSchemaSkeleton walks decoded JSON data and emits a starter draft
+// 2020-12 schema describing its shape, to path (or stdout when path is
+// empty). It's a starting point for maintainers to tighten by hand, not a
+// finished lock file: every object's keys are marked "required" and every
+// array's schema comes from its first element.
+func writeSchemaSkeleton(in interface{}, path string) {
+	skeleton := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     "https://arrow.apache.org/schemas/tmpl-data-v1.json",
+	}
+	for k, v := range schemaFor(in) {
+		skeleton[k] = v
+	}
+
+	out, err := json.MarshalIndent(skeleton, "", "  ")
+	if err != nil {
+		errExit("error generating schema: %s", err.Error())
+	}
+
+	if path == "" {
+		fmt.Println(string(out))
+		return
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		errExit("error writing schema '%s': %s", path, err.Error())
+	}
+}
+
+func schemaFor(v interface{}) map[string]interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		props := make(map[string]interface{}, len(t))
+		required := make([]string, 0, len(t))
+		for k, val := range t {
+			props[k] = schemaFor(val)
+			required = append(required, k)
+		}
+		sort.Strings(required)
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": props,
+			"required":   required,
+		}
+	case []interface{}:
+		items := map[string]interface{}{}
+		if len(t) > 0 {
+			items = schemaFor(t[0])
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case float64:
+		return map[string]interface{}{"type": "number"}
+	case nil:
+		return map[string]interface{}{"type": "null"}
+	default:
+		return map[string]interface{}{}
+	}
+}
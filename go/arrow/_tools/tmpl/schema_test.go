@@ -0,0 +1,121 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchemaDoc = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://arrow.apache.org/schemas/tmpl-data-v1.json",
+  "type": "object",
+  "properties": {
+    "Types": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "CType": {"type": "string"}
+        },
+        "required": ["CType"]
+      }
+    }
+  },
+  "required": ["Types"]
+}`
+
+func writeTestSchema(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(testSchemaDoc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSchemaVersion(t *testing.T) {
+	schema, version, err := loadSchema(writeTestSchema(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "v1" {
+		t.Errorf("version = %q, want %q", version, "v1")
+	}
+	if schema == nil {
+		t.Fatal("loadSchema returned a nil schema")
+	}
+}
+
+func TestValidateAgainstSchemaOK(t *testing.T) {
+	schema, _, err := loadSchema(writeTestSchema(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"Types": []interface{}{
+			map[string]interface{}{"CType": "int8_t"},
+		},
+	}
+	if err := validateAgainstSchema(schema, data); err != nil {
+		t.Errorf("expected valid data to pass, got: %s", err)
+	}
+}
+
+func TestValidateAgainstSchemaFailure(t *testing.T) {
+	schema, _, err := loadSchema(writeTestSchema(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// missing the required CType field
+	data := map[string]interface{}{
+		"Types": []interface{}{
+			map[string]interface{}{},
+		},
+	}
+	err = validateAgainstSchema(schema, data)
+	if err == nil {
+		t.Fatal("expected invalid data to fail validation")
+	}
+	if !strings.Contains(err.Error(), "/Types/0") {
+		t.Errorf("error = %q, want it to include the leaf instance location /Types/0", err.Error())
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	in := map[string]interface{}{
+		"name":  "Int8",
+		"width": 8.0,
+		"tags":  []interface{}{"a"},
+	}
+	got := schemaFor(in)
+	if got["type"] != "object" {
+		t.Errorf("type = %v, want object", got["type"])
+	}
+	props, ok := got["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatal("properties is not a map")
+	}
+	if nameSchema, ok := props["name"].(map[string]interface{}); !ok || nameSchema["type"] != "string" {
+		t.Errorf("properties.name = %#v, want type string", props["name"])
+	}
+}